@@ -0,0 +1,101 @@
+package lexer
+
+import "strings"
+
+// Dialect is the keyword table that drives identifier classification.
+// Instead of a hand-written state function per keyword, the lexer scans
+// any run of identifier characters once and asks the Dialect what it
+// means: a statement keyword, a clause keyword, or a plain identifier.
+//
+// Embedders can start from DefaultDialect and Register additional
+// keywords (e.g. RETURNING, WITH) without touching the state machine in
+// lex.go.
+type Dialect struct {
+	// Keywords maps the lower-cased spelling of every reserved word to
+	// its item type. Symbols such as "*" or ";" are looked up separately
+	// by the state functions that recognize them, so they don't need an
+	// entry here.
+	Keywords map[string]ItemType
+
+	// Statements is the subset of Keywords that may start a new
+	// statement (SELECT, INSERT, UPDATE, ...).
+	Statements map[ItemType]bool
+
+	// entryPoints maps a statement keyword to the state function that
+	// scans the rest of that statement. Only ItemSelect has one today;
+	// the other statement keywords in Statements are recognized but
+	// lexStartStatement reports them as unsupported.
+	entryPoints map[ItemType]stateFn
+}
+
+// DefaultDialect returns a Dialect describing the keywords understood by
+// the grammar implemented in this package.
+func DefaultDialect() *Dialect {
+	d := &Dialect{
+		Keywords: map[string]ItemType{
+			"select":   ItemSelect,
+			"insert":   ItemInsert,
+			"update":   ItemUpdate,
+			"delete":   ItemDelete,
+			"create":   ItemCreate,
+			"drop":     ItemDrop,
+			"begin":    ItemBegin,
+			"commit":   ItemCommit,
+			"rollback": ItemRollback,
+			"from":     ItemFrom,
+			"where":    ItemWhere,
+			"and":      ItemAnd,
+			"or":       ItemOr,
+			"not":      ItemNot,
+			"like":     ItemLike,
+			"is":       ItemIs,
+			"null":     ItemNull,
+			"as":       ItemAs,
+			"join":     ItemJoin,
+			"on":       ItemOn,
+			"order":    ItemOrder,
+			"by":       ItemBy,
+			"asc":      ItemAsc,
+			"desc":     ItemDesc,
+			"limit":    ItemLimit,
+			"offset":   ItemOffset,
+		},
+		Statements: map[ItemType]bool{
+			ItemSelect:   true,
+			ItemInsert:   true,
+			ItemUpdate:   true,
+			ItemDelete:   true,
+			ItemCreate:   true,
+			ItemDrop:     true,
+			ItemBegin:    true,
+			ItemCommit:   true,
+			ItemRollback: true,
+		},
+		entryPoints: map[ItemType]stateFn{
+			ItemSelect: lexValueExprList,
+		},
+	}
+	return d
+}
+
+// Register adds or overrides a keyword. Pass isStatement true if the
+// keyword may start a new statement; in that case nextAction is the
+// StateFn invoked immediately after the keyword is emitted. Passing a
+// nil nextAction for a statement keyword just makes lexStartStatement
+// recognize it and report it as unsupported, which is useful for
+// reserving a word ahead of implementing its grammar.
+func (d *Dialect) Register(word string, typ ItemType, isStatement bool, nextAction StateFn) {
+	d.Keywords[strings.ToLower(word)] = typ
+	if isStatement {
+		d.Statements[typ] = true
+		if nextAction != nil {
+			d.entryPoints[typ] = nextAction
+		}
+	}
+}
+
+// lookup resolves word against the keyword table, case-insensitively.
+func (d *Dialect) lookup(word string) (ItemType, bool) {
+	t, ok := d.Keywords[strings.ToLower(word)]
+	return t, ok
+}
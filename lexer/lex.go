@@ -1,131 +1,260 @@
-package main
+// Package lexer implements a hand-written scanner for a subset of SQL,
+// modeled on the state-function lexer from Rob Pike's "Lexical Scanning
+// in Go" talk and the lexer in text/template/parse.
+package lexer
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"unicode"
-	"unicode/utf8"
 )
 
 // Pos represents a byte position in the original input text from which
-// this template was parsed.
+// this statement was parsed.
 type Pos int
 
-// item represents a token or text string returned from the scanner.
-type item struct {
-	typ  itemType // The type of this item.
-	pos  Pos      // The starting position, in bytes, of this item in the input string.
-	val  string   // The value of this item.
-	line int      // The line number at the start of this item.
+// Item represents a token or text string returned from the scanner.
+type Item struct {
+	Typ  ItemType // The type of this item.
+	Pos  Pos      // The starting position, in bytes, of this item in the input string.
+	Val  string   // The value of this item.
+	Line int      // The line number at the start of this item.
+	Col  int      // The 1-based byte column, within Line, at the start of this item.
+
+	// Meta carries auxiliary data that doesn't fit in Val. For ItemParam
+	// it is the bind parameter's name ("name" for :name/@name) or
+	// ordinal ("1" for $1, or the auto-assigned position for a bare ?).
+	// Unused for every other item type.
+	Meta string
 }
 
-func (i item) String() string {
+func (i Item) String() string {
 	switch {
-	case i.typ == itemEOF:
+	case i.Typ == ItemEOF:
 		return "EOF"
-	case i.typ == itemError:
-		return i.val
-	case i.typ > itemKeyword:
-		return fmt.Sprintf("<%s>", i.val)
-	case len(i.val) > 10:
-		return fmt.Sprintf("%.10q...", i.val)
+	case i.Typ == ItemError:
+		return i.Val
+	case i.Typ > ItemKeyword:
+		return fmt.Sprintf("<%s>", i.Val)
+	case len(i.Val) > 10:
+		return fmt.Sprintf("%.10q...", i.Val)
 	}
-	return fmt.Sprintf("%q", i.val)
+	return fmt.Sprintf("%q", i.Val)
 }
 
-// itemType identifies the type of lex items.
-type itemType int
+// ItemType identifies the type of lex items.
+type ItemType int
 
 const (
-	itemError itemType = iota // error occurred;
-
-	itemEOF
-	itemIdentifier
-	itemKeyword
-	itemComma
-	itemStar
-	itemSelect
-	itemFrom
-	itemSemicolon
-)
+	ItemError ItemType = iota // error occurred; value is the text of the error
 
-var key = map[string]itemType{
-	"*":      itemStar,
-	"select": itemSelect,
-	"from":   itemFrom,
-	";":      itemSemicolon,
-	",":      itemSemicolon,
-}
+	ItemEOF
+	ItemIdentifier
+	ItemString
+	ItemNumber
+	ItemKeyword
+	ItemComma
+	ItemStar
+	ItemDot
+	ItemLeftParen
+	ItemRightParen
+	ItemSemicolon
+	ItemParam       // bind parameter: ?, $1, :name, or @name; ordinal/name in Item.Meta
+	ItemQuotedIdent // "double quoted" identifier, "" escaping
+	ItemBlob        // X'deadbeef' hex blob literal
+
+	// comparison and boolean operators
+	ItemEq
+	ItemNeq
+	ItemLt
+	ItemLte
+	ItemGt
+	ItemGte
+
+	// statement-entry keywords; see Dialect.Statements
+	ItemSelect
+	ItemInsert
+	ItemUpdate
+	ItemDelete
+	ItemCreate
+	ItemDrop
+	ItemBegin
+	ItemCommit
+	ItemRollback
+
+	// other keywords
+	ItemFrom
+	ItemWhere
+	ItemAnd
+	ItemOr
+	ItemNot
+	ItemLike
+	ItemIs
+	ItemNull
+	ItemAs
+	ItemJoin
+	ItemOn
+	ItemOrder
+	ItemBy
+	ItemAsc
+	ItemDesc
+	ItemLimit
+	ItemOffset
+)
 
 const eof = -1
 
 const (
 	spaceChars = " \t\r\n" // These are the space characters defined by Go itself.
-
 )
 
-// stateFn represents the state of the scanner as a function that returns the next state.
-type stateFn func(*lexer) stateFn
+// StateFn represents the state of the scanner as a function that returns
+// the next state, or nil to stop the scan. It is exported so embedders
+// can write their own state functions and wire them up via
+// Dialect.Register's nextAction parameter.
+type StateFn func(*Lexer) StateFn
+
+// stateFn is the internal name used throughout this file for brevity; it
+// is the same type as StateFn.
+type stateFn = StateFn
+
+// bufferedRune is one rune sitting in the lexer's read-ahead ring,
+// together with its encoded width so Pos/backup bookkeeping stays
+// correct without re-decoding UTF-8.
+type bufferedRune struct {
+	r         rune
+	width     Pos
+	lineStart Pos // l.lineStart as it was immediately before this rune was consumed
+}
 
-// lexer holds the state of the scanner.
-type lexer struct {
-	name      string    // the name of the input; used only for error reports
-	input     string    // the string being scanned
-	pos       Pos       // current position in the input
-	start     Pos       // start position of this item
-	width     Pos       // width of last rune read from input
-	items     chan item // channel of scanned items
-	line      int       // 1+number of newlines seen
-	startLine int       // start line of this item
+// Lexer holds the state of the scanner. It reads runes lazily from r, so
+// the full input never needs to be resident in memory.
+type Lexer struct {
+	name           string         // the name of the input; used only for error reports
+	r              io.RuneReader  // the source being scanned
+	dialect        *Dialect       // keyword table driving identifier classification
+	ahead          []bufferedRune // runes read from r but pushed back by backup, oldest first
+	pending        []bufferedRune // runes of the item currently being scanned, from start to pos
+	pos            Pos            // current cumulative byte offset in the input
+	start          Pos            // start byte offset of this item
+	atEOF          bool           // true once r has reported io.EOF
+	lastEOF        bool           // true if the latest next() returned eof, so the backup() after it has nothing to undo
+	items          chan Item      // channel of scanned items
+	done           chan struct{}  // closed by Close to abort the run goroutine early
+	closeOnce      sync.Once      // guards done so Close can be called more than once
+	line           int            // 1+number of newlines seen
+	startLine      int            // start line of this item
+	lineStart      Pos            // byte offset of the first byte of the current line
+	startLineStart Pos            // lineStart as of the start of this item, for column accounting
+	paramNum       int            // count of bare ? parameters seen so far, for auto ordinals
 }
 
 // next returns the next rune in the input.
-func (l *lexer) next() rune {
-	if int(l.pos) >= len(l.input) {
-		l.width = 0
+func (l *Lexer) next() rune {
+	var br bufferedRune
+	if n := len(l.ahead); n > 0 {
+		br = l.ahead[0]
+		l.ahead = l.ahead[1:]
+	} else if l.atEOF {
+		l.lastEOF = true
 		return eof
+	} else {
+		r, w, err := l.r.ReadRune()
+		if err != nil {
+			l.atEOF = true
+			l.lastEOF = true
+			return eof
+		}
+		br = bufferedRune{r: r, width: Pos(w), lineStart: l.lineStart}
 	}
-	r, w := utf8.DecodeRuneInString(l.input[l.pos:])
-	l.width = Pos(w)
-	l.pos += l.width
-	if r == '\n' {
+
+	l.lastEOF = false
+	l.pending = append(l.pending, br)
+	l.pos += br.width
+	if br.r == '\n' {
 		l.line++
+		l.lineStart = l.pos
 	}
-	return r
+	return br.r
 }
 
 // peek returns but does not consume the next rune in the input.
-func (l *lexer) peek() rune {
+func (l *Lexer) peek() rune {
 	r := l.next()
 	l.backup()
 	return r
 }
 
-// backup steps back one rune. Can only be called once per call of next.
-func (l *lexer) backup() {
-	l.pos -= l.width
-	// Correct newline count.
-	if l.width == 1 && l.input[l.pos] == '\n' {
+// backup pushes the most recently read rune back onto the read-ahead
+// ring. It may be called repeatedly to back up over several runes, as
+// long as each call undoes a prior next().
+func (l *Lexer) backup() {
+	if l.lastEOF {
+		l.lastEOF = false
+		return
+	}
+	n := len(l.pending)
+	if n == 0 {
+		return
+	}
+	br := l.pending[n-1]
+	l.pending = l.pending[:n-1]
+	l.ahead = append([]bufferedRune{br}, l.ahead...)
+	l.pos -= br.width
+	if br.r == '\n' {
 		l.line--
 	}
+	l.lineStart = br.lineStart
+}
+
+// emit passes an item back to the client, materializing its text from
+// the runes accumulated in pending since the last emit/ignore. If the
+// client has called Close, emit gives up on delivering the item instead
+// of blocking forever on an unbuffered send nobody will receive.
+func (l *Lexer) emit(t ItemType) {
+	l.emitItem(Item{Typ: t, Pos: l.start, Val: runesToString(l.pending), Line: l.startLine, Col: l.startCol()})
 }
 
-// emit passes an item back to the client.
-func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.start, l.input[l.start:l.pos], l.startLine}
+// emitMeta is like emit but also sets Item.Meta, e.g. the ordinal or
+// name of an ItemParam.
+func (l *Lexer) emitMeta(t ItemType, meta string) {
+	l.emitItem(Item{Typ: t, Pos: l.start, Val: runesToString(l.pending), Line: l.startLine, Col: l.startCol(), Meta: meta})
+}
+
+func (l *Lexer) emitItem(item Item) {
+	select {
+	case l.items <- item:
+	case <-l.done:
+	}
+	l.pending = nil
 	l.start = l.pos
 	l.startLine = l.line
+	l.startLineStart = l.lineStart
+}
+
+// startCol returns the 1-based column, within startLine, of l.start.
+func (l *Lexer) startCol() int {
+	return int(l.start-l.startLineStart) + 1
 }
 
 // ignore skips over the pending input before this point.
-func (l *lexer) ignore() {
-	l.line += strings.Count(l.input[l.start:l.pos], "\n")
+func (l *Lexer) ignore() {
+	for _, br := range l.pending {
+		if br.r == '\n' {
+			l.line++
+		}
+	}
+	l.pending = nil
 	l.start = l.pos
 	l.startLine = l.line
+	l.startLineStart = l.lineStart
 }
 
 // accept consumes the next rune if it's from the valid set.
-func (l *lexer) accept(valid string) bool {
+func (l *Lexer) accept(valid string) bool {
 	if strings.ContainsRune(valid, l.next()) {
 		return true
 	}
@@ -134,38 +263,86 @@ func (l *lexer) accept(valid string) bool {
 }
 
 // acceptRun consumes a run of runes from the valid set.
-func (l *lexer) acceptRun(valid string) {
+func (l *Lexer) acceptRun(valid string) {
 	for strings.ContainsRune(valid, l.next()) {
 	}
 	l.backup()
 }
 
+// runesToString renders the runes of a pending item back into a string.
+func runesToString(brs []bufferedRune) string {
+	var b strings.Builder
+	for _, br := range brs {
+		b.WriteRune(br.r)
+	}
+	return b.String()
+}
+
 // errorf returns an error token and terminates the scan by passing
-// back a nil pointer that will be the next state, terminating l.nextItem.
-func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{itemError, l.start, fmt.Sprintf(format, args...), l.startLine}
+// back a nil pointer that will be the next state, terminating the run
+// loop.
+func (l *Lexer) errorf(format string, args ...interface{}) stateFn {
+	item := Item{Typ: ItemError, Pos: l.start, Val: fmt.Sprintf(format, args...), Line: l.startLine, Col: l.startCol()}
+	select {
+	case l.items <- item:
+	case <-l.done:
+	}
 	return nil
 }
 
-// nextItem returns the next item from the input.
-// Called by the parser, not in the lexing goroutine.
-func (l *lexer) nextItem() item {
-	return <-l.items
+// NextItem returns the next item from the input and true, or a zero
+// Item and false once the lexing goroutine has finished (after an
+// ItemEOF/ItemError item, or after Close). Called by the parser, not in
+// the lexing goroutine.
+func (l *Lexer) NextItem() (Item, bool) {
+	item, ok := <-l.items
+	return item, ok
 }
 
-// drain drains the output so the lexing goroutine will exit.
-// Called by the parser, not in the lexing goroutine.
-func (l *lexer) drain() {
+// Drain reads and discards items until the lexing goroutine finishes on
+// its own. Prefer Close for aborting early: Drain only returns once the
+// scan has run to completion, which can be a long wait on a malformed
+// or very large statement.
+func (l *Lexer) Drain() {
 	for range l.items {
 	}
 }
 
-// lex creates a new scanner for the input string.
-func lex(name, input string) *lexer {
-	l := &lexer{
+// Close signals the lexing goroutine to stop: from then on emit and
+// errorf give up on items nobody receives, and run returns before the
+// next state function, closing the item channel as it exits. Safe to call more than
+// once, and safe to call whether or not the scan has already finished.
+// Callers that abandon a Lexer before reading it to completion (e.g. a
+// parser that bails out on the first syntax error) must call Close to
+// avoid leaking the goroutine.
+func (l *Lexer) Close() {
+	l.closeOnce.Do(func() { close(l.done) })
+}
+
+// Lex creates a new scanner that reads runes from r, using the default
+// dialect. Large inputs, or input streamed from a socket or file, never
+// need to be buffered in full: the lexer only keeps the runes of the
+// item it is currently scanning, plus whatever backup() has pushed back.
+func Lex(name string, r io.RuneReader) *Lexer {
+	return LexDialect(name, r, DefaultDialect())
+}
+
+// LexString is a convenience wrapper around Lex for callers that already
+// have the whole statement in memory.
+func LexString(name, input string) *Lexer {
+	return Lex(name, bufio.NewReader(strings.NewReader(input)))
+}
+
+// LexDialect is like Lex but scans keywords from a caller-supplied
+// Dialect instead of DefaultDialect, so embedders can register extra
+// keywords (e.g. RETURNING, WITH) without touching the state machine.
+func LexDialect(name string, r io.RuneReader, d *Dialect) *Lexer {
+	l := &Lexer{
 		name:      name,
-		input:     input,
-		items:     make(chan item),
+		r:         r,
+		dialect:   d,
+		items:     make(chan Item),
+		done:      make(chan struct{}),
 		line:      1,
 		startLine: 1,
 	}
@@ -173,57 +350,731 @@ func lex(name, input string) *lexer {
 	return l
 }
 
-// run runs the state machine for the lexer.
-func (l *lexer) run(start stateFn) {
+// run runs the state machine for the lexer, stopping early if Close is
+// called while a state function is still running.
+func (l *Lexer) run(start stateFn) {
+	defer close(l.items)
 	for state := start; state != nil; {
+		select {
+		case <-l.done:
+			return
+		default:
+		}
 		state = state(l)
 	}
-	close(l.items)
 }
 
-func lexStartStatement(l *lexer) stateFn {
-	fmt.Println("lexStartStatement")
+// lexStartStatement scans the leading keyword of a statement and hands
+// off to the state function for that statement type. Only SELECT has a
+// grammar implemented past this point; the other statement keywords are
+// recognized so Dialect extensions can hook in their own nextAction.
+func lexStartStatement(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if l.peek() == eof {
+		return l.errorf("not finished statement")
+	}
+	if !l.scanIdentifier() {
+		return l.errorf("unrecognized character at start of statement: %#U", l.next())
+	}
+
+	word := l.pendingText()
+	typ, ok := l.dialect.lookup(word)
+	if !ok || !l.dialect.Statements[typ] {
+		return l.errorf("unrecognized statement: %q", word)
+	}
+	l.emit(typ)
+
+	if next, ok := l.dialect.entryPoints[typ]; ok {
+		return next
+	}
+	return l.errorf("statement type %q is not supported yet", word)
+}
+
+func lexValueExprList(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.next(); {
+	case r == '*':
+		l.emit(ItemStar)
+	case r == '\'':
+		l.backup()
+		if !l.scanString() {
+			return l.errorf("bad string: %q", l.pendingText())
+		}
+		l.emit(ItemString)
+	case r == '+' || r == '-' || ('0' <= r && r <= '9'):
+		l.backup()
+		if !l.scanNumber() {
+			return l.errorf("bad number: %q", l.pendingText())
+		}
+		l.emit(ItemNumber)
+	case r == '"':
+		l.backup()
+		if !l.scanQuotedIdent() {
+			return l.errorf("bad quoted identifier: %q", l.pendingText())
+		}
+		l.emit(ItemQuotedIdent)
+	case r == '?':
+		l.paramNum++
+		l.emitMeta(ItemParam, fmt.Sprintf("%d", l.paramNum))
+	case r == '$':
+		if !l.accept("0123456789") {
+			return l.errorf("bad parameter: expected a number after $")
+		}
+		l.acceptRun("0123456789")
+		ordinal := l.pendingText()[1:]
+		l.emitMeta(ItemParam, ordinal)
+	case r == ':' || r == '@':
+		if !l.scanParamName() {
+			return l.errorf("bad parameter: expected a name after %q", string(r))
+		}
+		name := l.pendingText()[1:]
+		l.emitMeta(ItemParam, name)
+	case (r == 'x' || r == 'X') && l.peek() == '\'':
+		if !l.scanBlob() {
+			return l.errorf("bad blob literal: %q", l.pendingText())
+		}
+		l.emit(ItemBlob)
+	case isAlpha(r):
+		l.backup()
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		if l.peek() == '(' {
+			l.emitWordOrKeyword()
+			l.next()
+			l.emit(ItemLeftParen)
+			return lexValueExprFuncArgs
+		}
+		l.emitWordOrKeyword()
+	default:
+		return l.errorf("unrecognized character at value expr: %#U", r)
+	}
+
+	return lexValueExprTail
+}
+
+// lexValueExprTail decides whether the value expression just scanned is
+// followed by a comma (another column), a dot (qualified name), an AS
+// alias, or FROM (the end of the column list).
+func lexValueExprTail(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.next(); {
+	case r == ',':
+		l.emit(ItemComma)
+		return lexValueExprList
+	case r == '.':
+		l.emit(ItemDot)
+		return lexValueExprList
+	case isAlpha(r):
+		l.backup()
+		return lexAfterValueExpr
+	default:
+		return l.errorf("unrecognized character after value expr: %#U", r)
+	}
+}
+
+// lexValueExprFuncArgs scans a function call's argument list in a SELECT
+// column position, e.g. the "*" in count(*) or the comma-separated
+// columns in coalesce(a, b). Once the closing ')' is scanned, the call
+// is itself a complete value expression, so it rejoins
+// lexValueExprTail.
+func lexValueExprFuncArgs(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if l.accept(")") {
+		l.emit(ItemRightParen)
+		return lexValueExprTail
+	}
+
+	switch r := l.next(); {
+	case r == '*':
+		l.emit(ItemStar)
+	case r == '\'':
+		l.backup()
+		if !l.scanString() {
+			return l.errorf("bad string: %q", l.pendingText())
+		}
+		l.emit(ItemString)
+	case r == '+' || r == '-' || ('0' <= r && r <= '9'):
+		l.backup()
+		if !l.scanNumber() {
+			return l.errorf("bad number: %q", l.pendingText())
+		}
+		l.emit(ItemNumber)
+	case isAlpha(r):
+		l.backup()
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		l.emitWordOrKeyword()
+	default:
+		return l.errorf("unrecognized character in function arguments: %#U", r)
+	}
+
+	l.skipWhitespace()
+	switch {
+	case l.accept(","):
+		l.emit(ItemComma)
+		return lexValueExprFuncArgs
+	case l.accept(")"):
+		l.emit(ItemRightParen)
+		return lexValueExprTail
+	default:
+		return l.errorf("expected , or ) in function arguments, got %#U", l.peek())
+	}
+}
+
+// lexAfterValueExpr scans the word following a result column (AS alias,
+// FROM, or nothing) and dispatches on whichever keyword it resolves to.
+func lexAfterValueExpr(l *Lexer) stateFn {
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	word := l.pendingText()
+	typ := l.lookupPending()
+	l.emit(typ)
+
+	switch typ {
+	case ItemAs:
+		return lexValueExprAlias
+	case ItemFrom:
+		return lexFromTableName
+	default:
+		return l.errorf("unrecognized keyword after value expr: %q", word)
+	}
+}
+
+func lexValueExprAlias(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	l.emit(ItemIdentifier)
+	return lexValueExprListTail
+}
+
+// lexValueExprListTail resumes the comma/FROM dispatch after a result
+// column has been fully scanned (including an optional AS alias).
+func lexValueExprListTail(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.next(); {
+	case r == ',':
+		l.emit(ItemComma)
+		return lexValueExprList
+	case isAlpha(r):
+		l.backup()
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		word := l.pendingText()
+		typ := l.lookupPending()
+		l.emit(typ)
+		if typ != ItemFrom {
+			return l.errorf("expected FROM, got %q", word)
+		}
+		return lexFromTableName
+	default:
+		return l.errorf("unrecognized character after value expr: %#U", r)
+	}
+}
+
+func lexFromTableName(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	l.emitWordOrKeyword()
+	return lexAfterTableName
+}
+
+// lexAfterTableName decides whether the table reference continues with a
+// comma or JOIN, or whether the FROM clause is done and the statement
+// moves into WHERE/ORDER BY/LIMIT/the end.
+func lexAfterTableName(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.peek(); {
+	case r == ',':
+		l.next()
+		l.emit(ItemComma)
+		return lexFromTableName
+	case isAlpha(r):
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		word := l.pendingText()
+		typ := l.lookupPending()
+		l.emit(typ)
+		switch typ {
+		case ItemJoin:
+			return lexJoinTableName
+		case ItemWhere:
+			return lexExpr
+		case ItemOrder:
+			return lexByKeyword
+		case ItemLimit:
+			return lexLimitNumber
+		default:
+			return l.errorf("unexpected keyword after FROM clause: %q", word)
+		}
+	default:
+		return lexEndOfStatement
+	}
+}
+
+func lexJoinTableName(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	l.emitWordOrKeyword()
+
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	word := l.pendingText()
+	typ := l.lookupPending()
+	l.emit(typ)
+	if typ != ItemOn {
+		return l.errorf("expected ON, got %q", word)
+	}
+	return lexExpr
+}
+
+// lexExpr scans a single expression token and loops until it hits a
+// clause boundary (ORDER/LIMIT/JOIN/; terminating WHERE/ON/boolean
+// expressions).
+func lexExpr(l *Lexer) stateFn {
+	l.skipWhitespace()
 	switch r := l.next(); {
 	case r == eof:
-		return l.errorf("not finished statement")
-	case isSpace(r) || isEndOfLine(r):
-		// nothing spaces before first keyword like SELECT
-	case r == 's' || r == 'S':
+		return l.errorf("unterminated expression")
+	case r == '(':
+		l.emit(ItemLeftParen)
+		return lexExpr
+	case r == ')':
+		l.emit(ItemRightParen)
+		return lexAfterExprToken
+	case r == '\'':
+		l.backup()
+		if !l.scanString() {
+			return l.errorf("bad string: %q", l.pendingText())
+		}
+		l.emit(ItemString)
+		return lexAfterExprToken
+	case r == '+' || r == '-' || ('0' <= r && r <= '9'):
 		l.backup()
-		return lexSelect
+		if !l.scanNumber() {
+			return l.errorf("bad number: %q", l.pendingText())
+		}
+		l.emit(ItemNumber)
+		return lexAfterExprToken
+	case r == '=':
+		l.emit(ItemEq)
+		return lexExpr
+	case r == '!':
+		if !l.accept("=") {
+			return l.errorf("expected = after !")
+		}
+		l.emit(ItemNeq)
+		return lexExpr
+	case r == '<':
+		if l.accept("=") {
+			l.emit(ItemLte)
+		} else if l.accept(">") {
+			l.emit(ItemNeq)
+		} else {
+			l.emit(ItemLt)
+		}
+		return lexExpr
+	case r == '>':
+		if l.accept("=") {
+			l.emit(ItemGte)
+		} else {
+			l.emit(ItemGt)
+		}
+		return lexExpr
+	case r == '"':
+		l.backup()
+		if !l.scanQuotedIdent() {
+			return l.errorf("bad quoted identifier: %q", l.pendingText())
+		}
+		l.emit(ItemQuotedIdent)
+		return lexAfterExprToken
+	case r == '?':
+		l.paramNum++
+		l.emitMeta(ItemParam, fmt.Sprintf("%d", l.paramNum))
+		return lexAfterExprToken
+	case r == '$':
+		if !l.accept("0123456789") {
+			return l.errorf("bad parameter: expected a number after $")
+		}
+		l.acceptRun("0123456789")
+		ordinal := l.pendingText()[1:]
+		l.emitMeta(ItemParam, ordinal)
+		return lexAfterExprToken
+	case r == ':' || r == '@':
+		if !l.scanParamName() {
+			return l.errorf("bad parameter: expected a name after %q", string(r))
+		}
+		name := l.pendingText()[1:]
+		l.emitMeta(ItemParam, name)
+		return lexAfterExprToken
+	case (r == 'x' || r == 'X') && l.peek() == '\'':
+		if !l.scanBlob() {
+			return l.errorf("bad blob literal: %q", l.pendingText())
+		}
+		l.emit(ItemBlob)
+		return lexAfterExprToken
+	case isAlpha(r):
+		l.backup()
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		typ := l.lookupPending()
+		l.emit(typ)
+		if typ == ItemNot {
+			// NOT at the start of an expression negates the operand
+			// that follows it, rather than completing one.
+			return lexExpr
+		}
+		return lexAfterExprToken
 	default:
-		return l.errorf("unrecognized character in action: %#U", r)
+		return l.errorf("unrecognized character in expression: %#U", r)
 	}
-	return lexStartStatement
 }
 
-func createLexKeyword(keyword string, it itemType, nextAction stateFn) stateFn {
-	lower := []rune(strings.ToLower(keyword))
-	upper := []rune(strings.ToUpper(keyword))
+// lexAfterExprToken is reached once a complete operand has been emitted;
+// it decides whether another operator/operand follows, or whether the
+// expression (and the clause it belongs to) is finished.
+func lexAfterExprToken(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.peek(); {
+	case r == '.':
+		l.next()
+		l.emit(ItemDot)
+		return lexExpr
+	case r == '=' || r == '!' || r == '<' || r == '>':
+		return lexExpr
+	case r == '(':
+		// An operand directly followed by '(' is a function call, e.g.
+		// the f in "f(x) = 1"; its argument list is just another
+		// expression (or list of them, comma-separated).
+		l.next()
+		l.emit(ItemLeftParen)
+		return lexExpr
+	case r == ',':
+		// Valid between a function call's arguments, and after a JOIN's
+		// ON condition ahead of the next table in the FROM list; anywhere
+		// else this is a syntax error the parser will catch.
+		l.next()
+		l.emit(ItemComma)
+		return lexExpr
+	case r == ')':
+		l.next()
+		l.emit(ItemRightParen)
+		return lexAfterExprToken
+	case isAlpha(r):
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		word := l.pendingText()
+		typ := l.lookupPending()
+		switch typ {
+		case ItemAnd, ItemOr, ItemNot, ItemLike, ItemIs:
+			l.emit(typ)
+			return lexExpr
+		case ItemJoin:
+			l.emit(typ)
+			return lexJoinTableName
+		case ItemOrder:
+			l.emit(typ)
+			return lexByKeyword
+		case ItemLimit:
+			l.emit(typ)
+			return lexLimitNumber
+		default:
+			return l.errorf("unexpected keyword in expression: %q", word)
+		}
+	case r == ';' || r == eof:
+		return lexEndOfStatement
+	default:
+		return lexEndOfStatement
+	}
+}
 
-	return func(l *lexer) stateFn {
-		l.start = l.pos
-		fmt.Printf("lexKeyword %v\n", keyword)
-		for index, lowerCaseRune := range lower {
-			upperCaseRune := upper[index]
-			r := l.next()
-			if !(r == lowerCaseRune || r == upperCaseRune) {
-				return l.errorf("expected %c or %c at pos %v in keyword %v, but got %c", lowerCaseRune, upperCaseRune, index, keyword, r)
+func lexByKeyword(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	word := l.pendingText()
+	typ := l.lookupPending()
+	l.emit(typ)
+	if typ != ItemBy {
+		return l.errorf("expected BY, got %q", word)
+	}
+	return lexOrderByColumn
+}
+
+func lexOrderByColumn(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	l.emitWordOrKeyword()
+	return lexOrderByTail
+}
+
+func lexOrderByTail(l *Lexer) stateFn {
+	l.skipWhitespace()
+	switch r := l.peek(); {
+	case r == ',':
+		l.next()
+		l.emit(ItemComma)
+		return lexOrderByColumn
+	case isAlpha(r):
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		word := l.pendingText()
+		typ := l.lookupPending()
+		l.emit(typ)
+		switch typ {
+		case ItemAsc, ItemDesc:
+			l.skipWhitespace()
+			if r := l.peek(); r == ',' {
+				l.next()
+				l.emit(ItemComma)
+				return lexOrderByColumn
 			}
+			return lexOrderByLimit
+		case ItemLimit:
+			return lexLimitNumber
+		default:
+			return l.errorf("unexpected keyword in ORDER BY clause: %q", word)
+		}
+	default:
+		return lexEndOfStatement
+	}
+}
+
+func lexOrderByLimit(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if r := l.peek(); !isAlpha(r) {
+		return lexEndOfStatement
+	}
+	if !l.scanIdentifier() {
+		return l.errorf("bad identifier: %q", l.pendingText())
+	}
+	word := l.pendingText()
+	typ := l.lookupPending()
+	l.emit(typ)
+	if typ != ItemLimit {
+		return l.errorf("unexpected keyword after ORDER BY: %q", word)
+	}
+	return lexLimitNumber
+}
+
+func lexLimitNumber(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanNumber() {
+		return l.errorf("bad number: %q", l.pendingText())
+	}
+	l.emit(ItemNumber)
+
+	l.skipWhitespace()
+	if r := l.peek(); isAlpha(r) {
+		if !l.scanIdentifier() {
+			return l.errorf("bad identifier: %q", l.pendingText())
+		}
+		word := l.pendingText()
+		typ := l.lookupPending()
+		l.emit(typ)
+		if typ != ItemOffset {
+			return l.errorf("expected OFFSET, got %q", word)
 		}
-		l.emit(it)
-		return nextAction
+		return lexOffsetNumber
+	}
+	return lexEndOfStatement
+}
+
+func lexOffsetNumber(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if !l.scanNumber() {
+		return l.errorf("bad number: %q", l.pendingText())
 	}
+	l.emit(ItemNumber)
+	return lexEndOfStatement
 }
 
-var lexSelect = createLexKeyword("select", itemSelect, lexSelectList)
+func lexEndOfStatement(l *Lexer) stateFn {
+	l.skipWhitespace()
+	if l.accept(";") {
+		l.emit(ItemSemicolon)
+	} else {
+		return l.errorf("unterminated statement")
+	}
+	l.emit(ItemEOF)
 
-func lexSelectList(l *lexer) stateFn {
-	fmt.Println("lexSelectList")
-	l.emit(itemEOF)
 	return nil
 }
 
+// emitWordOrKeyword emits the just-scanned identifier as its matching
+// keyword item type if the dialect recognizes it as a reserved word, or
+// as a plain ItemIdentifier otherwise. Lookups are case-insensitive.
+func (l *Lexer) emitWordOrKeyword() {
+	l.emit(l.lookupPending())
+}
+
+// lookupPending resolves the text scanned so far for the current item
+// against the dialect's keyword table, without emitting it.
+func (l *Lexer) lookupPending() ItemType {
+	if t, ok := l.dialect.lookup(l.pendingText()); ok {
+		return t
+	}
+	return ItemIdentifier
+}
+
+func (l *Lexer) scanIdentifier() bool {
+	chars := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_"
+	charsAndDigits := chars + "0123456789"
+	// first character should not contain digits
+	if !l.accept(chars) {
+		return false
+	}
+	// next could be anything
+	l.acceptRun(charsAndDigits)
+	// Next thing mustn't be alphanumeric.
+
+	if isAlphaNumeric(l.peek()) {
+		l.next()
+		return false
+	}
+	return true
+}
+
+func (l *Lexer) skipWhitespace() {
+	l.acceptRun(spaceChars)
+	l.pending = nil
+	l.start = l.pos
+	l.startLine = l.line
+	l.startLineStart = l.lineStart
+}
+
+// pendingText renders the runes scanned so far for the current item,
+// i.e. the as-yet-unemitted text between start and pos. Used to report
+// the offending text in error messages and to look words up in the
+// dialect's keyword table.
+func (l *Lexer) pendingText() string {
+	return runesToString(l.pending)
+}
+
+func (l *Lexer) scanNumber() bool {
+	// Optional leading sign.
+	l.accept("+-")
+	// Is it hex?
+	digits := "0123456789_"
+	if l.accept("0") {
+		// Note: Leading 0 does not mean octal in floats.
+		if l.accept("xX") {
+			digits = "0123456789abcdefABCDEF_"
+		} else if l.accept("oO") {
+			digits = "01234567_"
+		} else if l.accept("bB") {
+			digits = "01_"
+		}
+	}
+	l.acceptRun(digits)
+	if l.accept(".") {
+		l.acceptRun(digits)
+	}
+	if len(digits) == 10+1 && l.accept("eE") {
+		l.accept("+-")
+		l.acceptRun("0123456789_")
+	}
+	if len(digits) == 16+6+1 && l.accept("pP") {
+		l.accept("+-")
+		l.acceptRun("0123456789_")
+	}
+	// Is it imaginary?
+	l.accept("i")
+	// Next thing mustn't be alphanumeric.
+	if isAlphaNumeric(l.peek()) {
+		l.next()
+		return false
+	}
+	return true
+}
+
+func (l *Lexer) scanString() bool {
+	if r := l.next(); r != '\'' {
+		l.backup()
+		return false
+	}
+	for {
+		switch l.next() {
+		case '\\':
+			if r := l.next(); r != eof && r != '\n' {
+				break
+			}
+			fallthrough
+		case eof, '\n':
+			return false
+		case '\'':
+			if r := l.next(); r != '\'' {
+				l.backup()
+				return true
+			}
+
+		}
+	}
+}
+
+// scanQuotedIdent scans a "double quoted" identifier, using "" as the
+// escape for a literal quote, mirroring scanString's structure.
+func (l *Lexer) scanQuotedIdent() bool {
+	if r := l.next(); r != '"' {
+		l.backup()
+		return false
+	}
+	for {
+		switch l.next() {
+		case eof, '\n':
+			return false
+		case '"':
+			if r := l.next(); r != '"' {
+				l.backup()
+				return true
+			}
+		}
+	}
+}
+
+// scanBlob scans an X'deadbeef'-style hex blob literal, with the leading
+// X/x already consumed by the caller.
+func (l *Lexer) scanBlob() bool {
+	if r := l.next(); r != '\'' {
+		l.backup()
+		return false
+	}
+	l.acceptRun("0123456789abcdefABCDEF")
+	if r := l.next(); r != '\'' {
+		l.backup()
+		return false
+	}
+	return true
+}
+
+// scanParamName scans the name following a :name or @name bind parameter.
+func (l *Lexer) scanParamName() bool {
+	chars := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_"
+	if !l.accept(chars) {
+		return false
+	}
+	l.acceptRun(chars + "0123456789")
+	return true
+}
+
 // isSpace reports whether r is a space character.
 func isSpace(r rune) bool {
 	return r == ' ' || r == '\t'
@@ -239,11 +1090,10 @@ func isAlphaNumeric(r rune) bool {
 	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
 
-func main() {
-	l := lex("TEST SELECTS", "update")
+func isAlpha(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
 
-	fmt.Println(l.nextItem())
-	fmt.Println(l.nextItem())
-	fmt.Println(l.nextItem())
-	fmt.Println(l.nextItem())
+func isDigit(r rune) bool {
+	return ('0' <= r && r <= '9')
 }
@@ -0,0 +1,129 @@
+package lexer
+
+import "testing"
+
+// collect drains every item from a Lexer, including the trailing EOF.
+func collect(t *testing.T, l *Lexer) []Item {
+	t.Helper()
+	var items []Item
+	for {
+		item, ok := l.NextItem()
+		if !ok {
+			t.Fatalf("item channel closed before ItemEOF")
+		}
+		items = append(items, item)
+		if item.Typ == ItemEOF || item.Typ == ItemError {
+			return items
+		}
+	}
+}
+
+func typesOf(items []Item) []ItemType {
+	typs := make([]ItemType, len(items))
+	for i, item := range items {
+		typs[i] = item.Typ
+	}
+	return typs
+}
+
+func itemTypesEqual(got, want []ItemType) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestLexIsNull checks that IS NULL lexes as ItemIs followed by ItemNull,
+// rather than breaking the precedence-climbing loop in the parser.
+func TestLexIsNull(t *testing.T) {
+	items := collect(t, LexString("TEST IS NULL", "select * from t where a is null;"))
+	want := []ItemType{
+		ItemSelect, ItemStar, ItemFrom, ItemIdentifier, ItemWhere,
+		ItemIdentifier, ItemIs, ItemNull, ItemSemicolon, ItemEOF,
+	}
+	if got := typesOf(items); !itemTypesEqual(got, want) {
+		t.Fatalf("got item types %v, want %v", got, want)
+	}
+}
+
+// TestLexParenExpr checks that a parenthesized WHERE expression closes
+// and reaches end of statement instead of running off looking for more
+// expression tokens.
+func TestLexParenExpr(t *testing.T) {
+	items := collect(t, LexString("TEST PAREN", "select * from t where (a = 1);"))
+	want := []ItemType{
+		ItemSelect, ItemStar, ItemFrom, ItemIdentifier, ItemWhere,
+		ItemLeftParen, ItemIdentifier, ItemEq, ItemNumber, ItemRightParen,
+		ItemSemicolon, ItemEOF,
+	}
+	if got := typesOf(items); !itemTypesEqual(got, want) {
+		t.Fatalf("got item types %v, want %v", got, want)
+	}
+}
+
+// TestLexNotAtStart checks that a leading NOT in a WHERE expression
+// negates the operand that follows it instead of being treated as a
+// completed operand itself.
+func TestLexNotAtStart(t *testing.T) {
+	items := collect(t, LexString("TEST NOT", "select * from t where not a = 1;"))
+	want := []ItemType{
+		ItemSelect, ItemStar, ItemFrom, ItemIdentifier, ItemWhere,
+		ItemNot, ItemIdentifier, ItemEq, ItemNumber, ItemSemicolon, ItemEOF,
+	}
+	if got := typesOf(items); !itemTypesEqual(got, want) {
+		t.Fatalf("got item types %v, want %v", got, want)
+	}
+}
+
+// TestLexFuncCallInColumnList checks that a function call in a SELECT
+// column list, including a bare "*" argument, lexes as a call rather
+// than failing on the '(' after the identifier.
+func TestLexFuncCallInColumnList(t *testing.T) {
+	items := collect(t, LexString("TEST FUNC COL", "select count(*) from t;"))
+	want := []ItemType{
+		ItemSelect, ItemIdentifier, ItemLeftParen, ItemStar, ItemRightParen,
+		ItemFrom, ItemIdentifier, ItemSemicolon, ItemEOF,
+	}
+	if got := typesOf(items); !itemTypesEqual(got, want) {
+		t.Fatalf("got item types %v, want %v", got, want)
+	}
+}
+
+// TestLexFuncCallInExpr checks that a function call in a WHERE
+// expression, e.g. f(x) = 1, lexes using the same '(' / ',' / ')'
+// handling as a parenthesized subexpression.
+func TestLexFuncCallInExpr(t *testing.T) {
+	items := collect(t, LexString("TEST FUNC EXPR", "select * from t where f(x) = 1;"))
+	want := []ItemType{
+		ItemSelect, ItemStar, ItemFrom, ItemIdentifier, ItemWhere,
+		ItemIdentifier, ItemLeftParen, ItemIdentifier, ItemRightParen,
+		ItemEq, ItemNumber, ItemSemicolon, ItemEOF,
+	}
+	if got := typesOf(items); !itemTypesEqual(got, want) {
+		t.Fatalf("got item types %v, want %v", got, want)
+	}
+}
+
+// TestLexColAcrossLines checks that Col reports a 1-based offset within
+// the current line, not a cumulative byte offset into the whole input.
+// "where bogus bogus" is a syntax error (an identifier can't follow
+// another with nothing between them), and the reported position is
+// that of the second "bogus", on line 3.
+func TestLexColAcrossLines(t *testing.T) {
+	items := collect(t, LexString("TEST COL", "select *\nfrom t\nwhere bogus bogus;"))
+	last := items[len(items)-1]
+	if last.Typ != ItemError {
+		t.Fatalf("got last item %v, want an ItemError", last)
+	}
+	if last.Line != 3 {
+		t.Errorf("got Line %d, want 3", last.Line)
+	}
+	if last.Col != 13 {
+		t.Errorf("got Col %d, want 13", last.Col)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/btd/godb/parser"
+)
+
+func main() {
+	p := parser.New("TEST SELECTS", "select col, col2 as c2 from t where t.col > 10 and c2 like 'ab%' order by col limit 10 offset 5;")
+
+	stmt, err := p.Parse()
+	if err != nil {
+		fmt.Println("ERROR", err)
+		return
+	}
+	fmt.Printf("%#v\n", stmt)
+}
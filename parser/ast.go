@@ -0,0 +1,93 @@
+// Package parser builds a typed AST from the tokens produced by the
+// lexer package.
+package parser
+
+import "github.com/btd/godb/lexer"
+
+// Stmt is implemented by every statement AST node. Only SELECT is
+// supported today.
+type Stmt interface {
+	stmtNode()
+}
+
+// SelectStmt is the AST for a SELECT ... FROM ... WHERE ... ORDER BY ...
+// LIMIT ... statement.
+type SelectStmt struct {
+	Columns []ResultColumn
+	From    []TableRef
+	Where   Expr // nil if there is no WHERE clause
+	OrderBy []OrderingTerm
+	Limit   Expr // nil if there is no LIMIT clause
+	Offset  Expr // nil if there is no OFFSET clause
+}
+
+func (*SelectStmt) stmtNode() {}
+
+// ResultColumn is one entry of the SELECT column list. Star is true for
+// a bare "*"; Expr is nil in that case.
+type ResultColumn struct {
+	Star  bool
+	Expr  Expr
+	Alias string // "" if no AS alias was given
+}
+
+// TableRef is one table reference in a FROM clause, optionally joined to
+// the previous one via an ON condition.
+type TableRef struct {
+	Name string
+	On   Expr // nil for the first table in a FROM list
+}
+
+// OrderingTerm is one entry of an ORDER BY clause.
+type OrderingTerm struct {
+	Expr Expr
+	Desc bool
+}
+
+// Expr is implemented by every expression AST node.
+type Expr interface {
+	exprNode()
+}
+
+// Literal is a string or number constant.
+type Literal struct {
+	Value string
+	Kind  lexer.ItemType // lexer.ItemString or lexer.ItemNumber
+}
+
+func (*Literal) exprNode() {}
+
+// ColumnRef is a (possibly table-qualified) column reference.
+type ColumnRef struct {
+	Table  string // "" if unqualified
+	Column string
+}
+
+func (*ColumnRef) exprNode() {}
+
+// BinaryExpr is a binary operator expression, e.g. comparisons and
+// AND/OR/LIKE.
+type BinaryExpr struct {
+	Op    lexer.ItemType
+	Left  Expr
+	Right Expr
+}
+
+func (*BinaryExpr) exprNode() {}
+
+// UnaryExpr is a unary operator expression: NOT x (Op lexer.ItemNot) or
+// x IS NULL (Op lexer.ItemIs).
+type UnaryExpr struct {
+	Op lexer.ItemType
+	X  Expr
+}
+
+func (*UnaryExpr) exprNode() {}
+
+// FuncCall is a function call expression, e.g. COUNT(*).
+type FuncCall struct {
+	Name string
+	Args []Expr
+}
+
+func (*FuncCall) exprNode() {}
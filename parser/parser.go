@@ -0,0 +1,381 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btd/godb/lexer"
+)
+
+// Error is returned by Parse when the token stream does not match the
+// grammar. It carries the position of the offending token so callers
+// can point the user at the right place in their query.
+type Error struct {
+	Pos  lexer.Pos
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at line %d col %d", e.Msg, e.Line, e.Col)
+}
+
+// Parser turns a token stream from lexer into a Stmt.
+type Parser struct {
+	name string
+	lex  *lexer.Lexer
+	tok  lexer.Item // current lookahead token
+}
+
+// New creates a Parser over the given SQL text.
+func New(name, input string) *Parser {
+	p := &Parser{
+		name: name,
+		lex:  lexer.LexString(name, input),
+	}
+	p.advance()
+	return p
+}
+
+// advance consumes the current lookahead token and fetches the next one.
+func (p *Parser) advance() {
+	tok, ok := p.lex.NextItem()
+	if !ok {
+		tok = lexer.Item{Typ: lexer.ItemEOF}
+	}
+	p.tok = tok
+}
+
+// errorf builds a structured Error and closes the lexer, since a parse
+// error means the parser is abandoning the token stream before it runs
+// to completion.
+func (p *Parser) errorf(format string, args ...interface{}) error {
+	p.lex.Close()
+	return &Error{Pos: p.tok.Pos, Line: p.tok.Line, Col: p.tok.Col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// expect consumes the current token if it has type typ, otherwise it
+// returns a structured error.
+func (p *Parser) expect(typ lexer.ItemType, what string) (lexer.Item, error) {
+	if p.tok.Typ == lexer.ItemError {
+		return lexer.Item{}, p.errorf("%s", p.tok.Val)
+	}
+	if p.tok.Typ != typ {
+		return lexer.Item{}, p.errorf("expected %s, got %v", what, p.tok)
+	}
+	tok := p.tok
+	p.advance()
+	return tok, nil
+}
+
+// Parse parses a single statement and returns its AST.
+func (p *Parser) Parse() (Stmt, error) {
+	switch p.tok.Typ {
+	case lexer.ItemSelect:
+		return p.parseSelect()
+	case lexer.ItemError:
+		return nil, p.errorf("%s", p.tok.Val)
+	default:
+		return nil, p.errorf("expected a statement, got %v", p.tok)
+	}
+}
+
+func (p *Parser) parseSelect() (*SelectStmt, error) {
+	if _, err := p.expect(lexer.ItemSelect, "SELECT"); err != nil {
+		return nil, err
+	}
+
+	stmt := &SelectStmt{}
+
+	for {
+		col, err := p.parseResultColumn()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Columns = append(stmt.Columns, col)
+		if p.tok.Typ != lexer.ItemComma {
+			break
+		}
+		p.advance()
+	}
+
+	if _, err := p.expect(lexer.ItemFrom, "FROM"); err != nil {
+		return nil, err
+	}
+
+	joined := false
+	for {
+		ref, err := p.parseTableRef(joined)
+		if err != nil {
+			return nil, err
+		}
+		stmt.From = append(stmt.From, ref)
+		if p.tok.Typ == lexer.ItemComma {
+			p.advance()
+			joined = false
+			continue
+		}
+		if p.tok.Typ == lexer.ItemJoin {
+			joined = true
+			continue
+		}
+		break
+	}
+
+	if p.tok.Typ == lexer.ItemWhere {
+		p.advance()
+		where, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Where = where
+	}
+
+	if p.tok.Typ == lexer.ItemOrder {
+		p.advance()
+		if _, err := p.expect(lexer.ItemBy, "BY"); err != nil {
+			return nil, err
+		}
+		for {
+			term, err := p.parseOrderingTerm()
+			if err != nil {
+				return nil, err
+			}
+			stmt.OrderBy = append(stmt.OrderBy, term)
+			if p.tok.Typ != lexer.ItemComma {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	if p.tok.Typ == lexer.ItemLimit {
+		p.advance()
+		lim, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Limit = lim
+
+		if p.tok.Typ == lexer.ItemOffset {
+			p.advance()
+			off, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			stmt.Offset = off
+		}
+	}
+
+	if _, err := p.expect(lexer.ItemSemicolon, ";"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(lexer.ItemEOF, "end of statement"); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseResultColumn() (ResultColumn, error) {
+	if p.tok.Typ == lexer.ItemStar {
+		p.advance()
+		return ResultColumn{Star: true}, nil
+	}
+
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return ResultColumn{}, err
+	}
+	col := ResultColumn{Expr: expr}
+
+	if p.tok.Typ == lexer.ItemAs {
+		p.advance()
+		name, err := p.expect(lexer.ItemIdentifier, "alias")
+		if err != nil {
+			return ResultColumn{}, err
+		}
+		col.Alias = name.Val
+	}
+	return col, nil
+}
+
+func (p *Parser) parseTableRef(joined bool) (TableRef, error) {
+	if joined {
+		if _, err := p.expect(lexer.ItemJoin, "JOIN"); err != nil {
+			return TableRef{}, err
+		}
+	}
+	name, err := p.expect(lexer.ItemIdentifier, "table name")
+	if err != nil {
+		return TableRef{}, err
+	}
+	ref := TableRef{Name: name.Val}
+
+	if joined {
+		if _, err := p.expect(lexer.ItemOn, "ON"); err != nil {
+			return TableRef{}, err
+		}
+		on, err := p.parseExpr(0)
+		if err != nil {
+			return TableRef{}, err
+		}
+		ref.On = on
+	}
+	return ref, nil
+}
+
+func (p *Parser) parseOrderingTerm() (OrderingTerm, error) {
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return OrderingTerm{}, err
+	}
+	term := OrderingTerm{Expr: expr}
+	switch p.tok.Typ {
+	case lexer.ItemAsc:
+		p.advance()
+	case lexer.ItemDesc:
+		term.Desc = true
+		p.advance()
+	}
+	return term, nil
+}
+
+// binding powers for precedence-climbing expression parsing; larger
+// binds tighter.
+var binaryPrec = map[lexer.ItemType]int{
+	lexer.ItemOr:  1,
+	lexer.ItemAnd: 2,
+	lexer.ItemEq:  3, lexer.ItemNeq: 3,
+	lexer.ItemLt: 3, lexer.ItemLte: 3, lexer.ItemGt: 3, lexer.ItemGte: 3,
+	lexer.ItemLike: 3, lexer.ItemIs: 3,
+}
+
+// parseExpr parses an expression using precedence climbing: it parses a
+// unary/primary term, then repeatedly absorbs binary operators whose
+// precedence is >= minPrec.
+func (p *Parser) parseExpr(minPrec int) (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		prec, ok := binaryPrec[p.tok.Typ]
+		if !ok || prec < minPrec {
+			return left, nil
+		}
+		op := p.tok.Typ
+		p.advance()
+
+		if op == lexer.ItemIs {
+			if _, err := p.expect(lexer.ItemNull, "NULL"); err != nil {
+				return nil, err
+			}
+			left = &UnaryExpr{Op: lexer.ItemIs, X: left}
+			continue
+		}
+
+		right, err := p.parseExpr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *Parser) parseUnary() (Expr, error) {
+	switch p.tok.Typ {
+	case lexer.ItemNot:
+		p.advance()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: lexer.ItemNot, X: x}, nil
+	default:
+		return p.parsePrimary()
+	}
+}
+
+func (p *Parser) parsePrimary() (Expr, error) {
+	switch p.tok.Typ {
+	case lexer.ItemLeftParen:
+		p.advance()
+		expr, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(lexer.ItemRightParen, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	case lexer.ItemString, lexer.ItemNumber:
+		tok := p.tok
+		p.advance()
+		return &Literal{Value: unquote(tok), Kind: tok.Typ}, nil
+
+	case lexer.ItemIdentifier:
+		tok := p.tok
+		p.advance()
+
+		if p.tok.Typ == lexer.ItemLeftParen {
+			return p.parseFuncCallArgs(tok.Val)
+		}
+
+		if p.tok.Typ == lexer.ItemDot {
+			p.advance()
+			col, err := p.expect(lexer.ItemIdentifier, "column name")
+			if err != nil {
+				return nil, err
+			}
+			return &ColumnRef{Table: tok.Val, Column: col.Val}, nil
+		}
+
+		return &ColumnRef{Column: tok.Val}, nil
+
+	default:
+		return nil, p.errorf("expected an expression, got %v", p.tok)
+	}
+}
+
+func (p *Parser) parseFuncCallArgs(name string) (Expr, error) {
+	if _, err := p.expect(lexer.ItemLeftParen, "("); err != nil {
+		return nil, err
+	}
+	call := &FuncCall{Name: name}
+
+	if p.tok.Typ == lexer.ItemStar {
+		p.advance()
+		call.Args = append(call.Args, &ColumnRef{Column: "*"})
+	} else if p.tok.Typ != lexer.ItemRightParen {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			if p.tok.Typ != lexer.ItemComma {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	if _, err := p.expect(lexer.ItemRightParen, ")"); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// unquote strips the surrounding quotes from a string literal and
+// collapses doubled '' escapes; number literals pass through unchanged.
+func unquote(tok lexer.Item) string {
+	if tok.Typ != lexer.ItemString {
+		return tok.Val
+	}
+	s := strings.TrimPrefix(strings.TrimSuffix(tok.Val, "'"), "'")
+	return strings.ReplaceAll(s, "''", "'")
+}
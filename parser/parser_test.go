@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/btd/godb/lexer"
+)
+
+// TestParseSelect exercises the statement shipped as main.go's own
+// example.
+func TestParseSelect(t *testing.T) {
+	stmt, err := New("TEST SELECT", "select col, col2 as c2 from t where t.col > 10 and c2 like 'ab%' order by col limit 10 offset 5;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel, ok := stmt.(*SelectStmt)
+	if !ok {
+		t.Fatalf("Parse returned %T, want *SelectStmt", stmt)
+	}
+
+	if len(sel.Columns) != 2 {
+		t.Fatalf("got %d columns, want 2", len(sel.Columns))
+	}
+	if sel.Columns[1].Alias != "c2" {
+		t.Errorf("got alias %q, want %q", sel.Columns[1].Alias, "c2")
+	}
+	if len(sel.From) != 1 || sel.From[0].Name != "t" {
+		t.Errorf("got From %+v, want a single table %q", sel.From, "t")
+	}
+	if sel.Where == nil {
+		t.Errorf("got nil Where, want a WHERE clause")
+	}
+	if len(sel.OrderBy) != 1 {
+		t.Errorf("got %d ORDER BY terms, want 1", len(sel.OrderBy))
+	}
+	if sel.Limit == nil || sel.Offset == nil {
+		t.Errorf("got Limit %v / Offset %v, want both set", sel.Limit, sel.Offset)
+	}
+}
+
+// TestParseSelectCommaJoinedFrom covers a FROM clause with multiple
+// tables separated by commas rather than JOIN ... ON.
+func TestParseSelectCommaJoinedFrom(t *testing.T) {
+	stmt, err := New("TEST COMMA FROM", "select * from t1, t2;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	if len(sel.From) != 2 {
+		t.Fatalf("got %d tables, want 2", len(sel.From))
+	}
+	if sel.From[0].Name != "t1" || sel.From[1].Name != "t2" {
+		t.Errorf("got From %+v, want t1 then t2", sel.From)
+	}
+}
+
+// TestParseSelectJoin covers a FROM clause using JOIN ... ON.
+func TestParseSelectJoin(t *testing.T) {
+	stmt, err := New("TEST JOIN", "select * from t1 join t2 on t1.id = t2.id;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	if len(sel.From) != 2 {
+		t.Fatalf("got %d tables, want 2", len(sel.From))
+	}
+	if sel.From[1].On == nil {
+		t.Errorf("got nil On for joined table, want the ON condition")
+	}
+}
+
+// TestParseError checks that a malformed statement returns a structured
+// Error carrying a position, rather than a plain error.
+func TestParseError(t *testing.T) {
+	_, err := New("TEST ERROR", "select from t;").Parse()
+	if err == nil {
+		t.Fatal("Parse: got nil error, want a parse error")
+	}
+	perr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("got error of type %T, want *parser.Error", err)
+	}
+	if perr.Line == 0 {
+		t.Errorf("got Error.Line == 0, want the 1-based line of the offending token")
+	}
+}
+
+// TestParseSelectParenExpr covers a parenthesized WHERE expression.
+func TestParseSelectParenExpr(t *testing.T) {
+	stmt, err := New("TEST PAREN", "select * from t where (a = 1);").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	bin, ok := sel.Where.(*BinaryExpr)
+	if !ok {
+		t.Fatalf("got Where of type %T, want *BinaryExpr", sel.Where)
+	}
+	if bin.Op != lexer.ItemEq {
+		t.Errorf("got Op %v, want ItemEq", bin.Op)
+	}
+}
+
+// TestParseSelectNot covers NOT at the start of a WHERE expression. NOT
+// binds to the primary that directly follows it, so "not a = 1" parses
+// as "(not a) = 1".
+func TestParseSelectNot(t *testing.T) {
+	stmt, err := New("TEST NOT", "select * from t where not a = 1;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	bin, ok := sel.Where.(*BinaryExpr)
+	if !ok {
+		t.Fatalf("got Where of type %T, want *BinaryExpr", sel.Where)
+	}
+	un, ok := bin.Left.(*UnaryExpr)
+	if !ok {
+		t.Fatalf("got Where.Left of type %T, want *UnaryExpr", bin.Left)
+	}
+	if un.Op != lexer.ItemNot {
+		t.Errorf("got Op %v, want ItemNot", un.Op)
+	}
+	if _, ok := un.X.(*ColumnRef); !ok {
+		t.Errorf("got X of type %T, want *ColumnRef", un.X)
+	}
+}
+
+// TestParseSelectIsNull covers the IS NULL special-case in parseExpr.
+func TestParseSelectIsNull(t *testing.T) {
+	stmt, err := New("TEST IS NULL", "select * from t where a is null;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	un, ok := sel.Where.(*UnaryExpr)
+	if !ok {
+		t.Fatalf("got Where of type %T, want *UnaryExpr", sel.Where)
+	}
+	if un.Op != lexer.ItemIs {
+		t.Errorf("got Op %v, want ItemIs", un.Op)
+	}
+}
+
+// TestParseSelectFuncCall covers a function call both in a result
+// column (count(*)) and in a WHERE expression (f(x) = 1).
+func TestParseSelectFuncCall(t *testing.T) {
+	stmt, err := New("TEST FUNC CALL", "select count(*) from t where f(x) = 1;").Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+
+	if len(sel.Columns) != 1 {
+		t.Fatalf("got %d columns, want 1", len(sel.Columns))
+	}
+	call, ok := sel.Columns[0].Expr.(*FuncCall)
+	if !ok {
+		t.Fatalf("got column expr of type %T, want *FuncCall", sel.Columns[0].Expr)
+	}
+	if call.Name != "count" {
+		t.Errorf("got Name %q, want %q", call.Name, "count")
+	}
+	if len(call.Args) != 1 {
+		t.Fatalf("got %d args, want 1", len(call.Args))
+	}
+	if ref, ok := call.Args[0].(*ColumnRef); !ok || ref.Column != "*" {
+		t.Errorf("got arg %+v, want a ColumnRef for \"*\"", call.Args[0])
+	}
+
+	bin, ok := sel.Where.(*BinaryExpr)
+	if !ok {
+		t.Fatalf("got Where of type %T, want *BinaryExpr", sel.Where)
+	}
+	if _, ok := bin.Left.(*FuncCall); !ok {
+		t.Errorf("got Where.Left of type %T, want *FuncCall", bin.Left)
+	}
+}